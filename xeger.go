@@ -1,9 +1,12 @@
 package xeger
 
 import (
+	"io"
 	"math/rand"
 	"regexp/syntax"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -22,6 +25,21 @@ func defaultSource() Source { return rand.NewSource(time.Now().UnixNano()) }
 
 const defaultLimit = 10
 
+// defaultInterestingRunes is the pool of edge-case runes used by
+// WithInterestingRunes when no pool is supplied: control characters, a BOM,
+// the replacement character, an RTL override, and a rune whose UTF-8 length
+// changes on case folding.
+var defaultInterestingRunes = []rune{
+	0x0000, // NUL
+	0x000B, // VT
+	0x001B, // ESC
+	0x007F, // DEL
+	0xFEFF, // BOM
+	0xFFFD, // replacement character
+	0x202E, // RTL override
+	0x023A, // Ⱥ, U+023A LATIN CAPITAL LETTER A WITH STROKE
+}
+
 // A Source represents a source of uniformly-distributed pseudo-random int64
 // values in the range [0, 1<<63).  This is a subset of interface rand.Source
 // to specify only the methods required by Xeger.
@@ -30,9 +48,14 @@ type Source interface {
 }
 
 type Xeger struct {
-	re     *syntax.Regexp
-	source Source
-	limit  int
+	re            *syntax.Regexp
+	source        Source
+	limit         int
+	unicodeTables []*unicode.RangeTable
+	recording     *[]choice
+
+	interestingRunes []rune
+	interestingProb  float64
 }
 
 func NewXeger(regex string, opts ...Option) (*Xeger, error) {
@@ -40,6 +63,7 @@ func NewXeger(regex string, opts ...Option) (*Xeger, error) {
 	if err != nil {
 		return nil, err
 	}
+	re = re.Simplify()
 	x := &Xeger{re: re}
 
 	for _, o := range opts {
@@ -85,19 +109,363 @@ func WithLimit(limit int) Option {
 	})
 }
 
+// WithUnicode returns an Option that causes Xeger to sample "." and character
+// classes (e.g. "\w", "[[:alpha:]]") from the given Unicode RangeTables
+// (e.g. unicode.Lu, unicode.Ll, unicode.Nd, unicode.P) instead of the default
+// ASCII printable set, encoding the result as UTF-8 rather than a single
+// byte. When a character class is generated under this option, its Rune
+// range pairs are intersected with the supplied tables so the result
+// satisfies both the class and the Unicode category constraint. If the
+// intersection is empty, the class is sampled from its own ranges as it is
+// without this option.
+func WithUnicode(tables ...*unicode.RangeTable) Option {
+	return optionFunc(func(x *Xeger) {
+		x.unicodeTables = tables
+	})
+}
+
+// WithInterestingRunes returns an Option that causes "." and character
+// classes to, with the given probability (e.g. 0.1 for 10%), emit an
+// edge-case rune drawn from runes instead of an ordinary one — provided the
+// rune actually satisfies the surrounding constraint (e.g. is inside the
+// char class range, or is not a newline for "."). If runes is empty, a
+// default pool of edge cases (NUL, VT, ESC, DEL, BOM, the replacement
+// character, an RTL override, and a rune whose UTF-8 length changes on case
+// folding) is used instead. This makes Xeger a much more effective fuzzing
+// input source for protocol parsers and string-handling code.
+func WithInterestingRunes(runes []rune, probability float64) Option {
+	return optionFunc(func(x *Xeger) {
+		if len(runes) == 0 {
+			runes = defaultInterestingRunes
+		}
+		x.interestingRunes = runes
+		x.interestingProb = probability
+	})
+}
+
 // Generate returns a string that matches the regular expression with which
 // Xeger was created.
 func (x *Xeger) Generate() string {
-	return x.generateFromRegexp(x.re)
+	// Routed through generateFromConcat, rather than generateFromRegexp
+	// directly, so that a top-level x.re that is itself a bare
+	// OpWordBoundary/OpNoWordBoundary (e.g. the pattern "\b") still gets
+	// reconciled; generateFromRegexp's own zero-width case leaves that to
+	// its caller.
+	s, _ := x.generateFromConcat([]*syntax.Regexp{x.re}, true, true)
+	return s
+}
+
+// choice records a single randInt(n) call made during generation, so that
+// generation can later be replayed deterministically for shrinking.
+type choice struct {
+	n     int
+	value int
+}
+
+// tapeSource is a Source that replays a fixed sequence of previously-recorded
+// choices instead of drawing new random numbers. Calls beyond the end of the
+// tape return 0.
+type tapeSource struct {
+	values []int
+	i      int
+}
+
+func (t *tapeSource) Int63() int64 {
+	if t.i >= len(t.values) {
+		return 0
+	}
+	v := t.values[t.i]
+	t.i++
+	return int64(v)
+}
+
+// GenerateShrinkable generates a string the same way Generate does, but also
+// records the random choices made along the way onto a "choice tape". It
+// returns the generated string and a Shrinker that can replay the tape with
+// modifications to find a smaller string that still reproduces a given
+// failure.
+func (x *Xeger) GenerateShrinkable() (string, *Shrinker) {
+	var tape []choice
+	x.recording = &tape
+	s, _ := x.generateFromConcat([]*syntax.Regexp{x.re}, true, true)
+	x.recording = nil
+
+	return s, &Shrinker{
+		re:               x.re,
+		limit:            x.limit,
+		unicodeTables:    x.unicodeTables,
+		interestingRunes: x.interestingRunes,
+		interestingProb:  x.interestingProb,
+		tape:             tape,
+	}
+}
+
+// Shrinker replays the choice tape recorded by GenerateShrinkable, with
+// modifications, to minimize a failing input.
+type Shrinker struct {
+	re               *syntax.Regexp
+	limit            int
+	unicodeTables    []*unicode.RangeTable
+	interestingRunes []rune
+	interestingProb  float64
+	tape             []choice
+}
+
+// replay regenerates the string produced by the given sequence of
+// per-choice values, reusing the Shrinker's regexp, limit, Unicode tables
+// and interesting-rune configuration — anything that can make randInt be
+// called a different number of times (and so desync the tape) must be
+// carried over here.
+func (s *Shrinker) replay(values []int) string {
+	x := &Xeger{
+		re:               s.re,
+		limit:            s.limit,
+		unicodeTables:    s.unicodeTables,
+		interestingRunes: s.interestingRunes,
+		interestingProb:  s.interestingProb,
+		source:           &tapeSource{values: values},
+	}
+	out, _ := x.generateFromConcat([]*syntax.Regexp{x.re}, true, true)
+	return out
+}
+
+// Shrink repeatedly reduces the recorded choice tape — trying to remove
+// contiguous runs (which shortens repetitions produced by OpStar/OpPlus/
+// OpRepeat), zero out entries, and halve entries — replaying it each time,
+// keeping any reduction for which predicate still reports a failure. It
+// returns the smallest string found that both matches the original regular
+// expression and satisfies predicate.
+func (s *Shrinker) Shrink(predicate func(string) bool) string {
+	values := make([]int, len(s.tape))
+	for i, c := range s.tape {
+		values[i] = c.value
+	}
+	best := s.replay(values)
+	if !predicate(best) {
+		return best
+	}
+
+	for {
+		reduced := false
+
+		for size := len(values); size > 0 && !reduced; size-- {
+			for start := 0; start+size <= len(values); start++ {
+				candidate := make([]int, 0, len(values)-size)
+				candidate = append(candidate, values[:start]...)
+				candidate = append(candidate, values[start+size:]...)
+
+				if out := s.replay(candidate); predicate(out) {
+					values, best, reduced = candidate, out, true
+					break
+				}
+			}
+		}
+		if reduced {
+			continue
+		}
+
+		for i, v := range values {
+			if v == 0 {
+				continue
+			}
+			candidate := append([]int(nil), values...)
+			candidate[i] = 0
+			if out := s.replay(candidate); predicate(out) {
+				values, best, reduced = candidate, out, true
+			}
+		}
+		if reduced {
+			continue
+		}
+
+		for i, v := range values {
+			if v == 0 {
+				continue
+			}
+			candidate := append([]int(nil), values...)
+			candidate[i] = v / 2
+			if out := s.replay(candidate); predicate(out) {
+				values, best, reduced = candidate, out, true
+			}
+		}
+		if !reduced {
+			return best
+		}
+	}
+}
+
+// xegerFrame is one entry of an xegerReader's work stack: a pass over subs,
+// with idx the next sub to process and reps the number of further passes to
+// make over subs once idx reaches len(subs) (used for OpStar/OpPlus/OpQuest/
+// OpRepeat; 0 for a plain single pass, as with OpConcat/OpCapture).
+type xegerFrame struct {
+	subs []*syntax.Regexp
+	idx  int
+	reps int
+}
+
+// xegerReader is an io.Reader that walks a Xeger's regexp on demand,
+// generating runes only as they're read rather than materializing the whole
+// string up front. This lets pathological patterns like ".*" under a very
+// large WithLimit be read incrementally, or truncated early, without allocating the full
+// expansion.
+//
+// Word-boundary reconciliation and "^"/"$" position enforcement (see
+// generateFromConcat) both require look-ahead or backtracking across a
+// whole concatenation and aren't performed here; patterns relying on
+// \b/\B/^/$ should use Generate or GenerateShrinkable instead.
+type xegerReader struct {
+	x       *Xeger
+	stack   []xegerFrame
+	pending []byte
+}
+
+// GenerateReader returns an io.Reader that streams a string matching the
+// regular expression with which Xeger was created, generating it
+// incrementally as it is read.
+func (x *Xeger) GenerateReader() io.Reader {
+	return &xegerReader{x: x, stack: []xegerFrame{{subs: []*syntax.Regexp{x.re}}}}
+}
+
+// GenerateN returns a string matching the regular expression with which
+// Xeger was created, truncated to at most maxBytes bytes. Unlike Generate,
+// it generates incrementally via GenerateReader, so a pattern with an
+// effectively unbounded expansion (e.g. ".*" with WithLimit(1000000)) can be capped
+// without first allocating its full, untruncated output.
+func (x *Xeger) GenerateN(maxBytes int) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	r := x.GenerateReader()
+	out := make([]byte, 0, maxBytes)
+	buf := make([]byte, 4096)
+	for len(out) < maxBytes {
+		n, err := r.Read(buf)
+		out = append(out, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	if len(out) > maxBytes {
+		out = out[:maxBytes]
+	}
+	// The cut above can land in the middle of a multi-byte rune (chunks from
+	// Read are whole runes, but maxBytes need not fall on a rune boundary);
+	// back off to the last full rune so the result is always valid UTF-8.
+	for len(out) > 0 {
+		if r, size := utf8.DecodeLastRune(out); r != utf8.RuneError || size != 1 {
+			break
+		}
+		out = out[:len(out)-1]
+	}
+	return string(out)
+}
+
+func (r *xegerReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		b, ok := r.next()
+		if !ok {
+			return 0, io.EOF
+		}
+		r.pending = b
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
 }
 
-// Generates strings which are matched with re.
-func (x *Xeger) generateFromRegexp(re *syntax.Regexp) string {
+// next advances the work stack until it produces the next chunk of
+// generated text, or returns ok=false once the stack is empty and the whole
+// regexp has been consumed.
+func (r *xegerReader) next() ([]byte, bool) {
+	for len(r.stack) > 0 {
+		top := &r.stack[len(r.stack)-1]
+		if top.idx >= len(top.subs) {
+			if top.reps > 0 {
+				top.reps--
+				top.idx = 0
+				continue
+			}
+			r.stack = r.stack[:len(r.stack)-1]
+			continue
+		}
+
+		sub := top.subs[top.idx]
+		top.idx++
+
+		switch sub.Op {
+		case syntax.OpLiteral:
+			if len(sub.Rune) == 0 {
+				continue
+			}
+			return []byte(string(sub.Rune)), true
+
+		case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			if s, _ := r.x.generateFromRegexp(sub, true, true); s != "" {
+				return []byte(s), true
+			}
+
+		case syntax.OpCapture, syntax.OpConcat:
+			r.stack = append(r.stack, xegerFrame{subs: sub.Sub})
+
+		case syntax.OpStar:
+			if count := r.x.randInt(r.x.limit + 1); count > 0 {
+				r.stack = append(r.stack, xegerFrame{subs: sub.Sub, reps: count - 1})
+			}
+
+		case syntax.OpPlus:
+			count := r.x.randInt(r.x.limit) + 1
+			r.stack = append(r.stack, xegerFrame{subs: sub.Sub, reps: count - 1})
+
+		case syntax.OpQuest:
+			if count := r.x.randInt(2); count > 0 {
+				r.stack = append(r.stack, xegerFrame{subs: sub.Sub, reps: count - 1})
+			}
+
+		case syntax.OpRepeat:
+			max := sub.Max
+			if max == -1 {
+				max = r.x.limit
+			}
+			if count := r.x.randInt(max-sub.Min+1) + sub.Min; count > 0 {
+				r.stack = append(r.stack, xegerFrame{subs: sub.Sub, reps: count - 1})
+			}
+
+		case syntax.OpAlternate:
+			i := r.x.randInt(len(sub.Sub))
+			r.stack = append(r.stack, xegerFrame{subs: sub.Sub[i : i+1]})
+
+			// OpEmptyMatch, OpBeginLine, OpEndLine, OpBeginText, OpEndText,
+			// OpWordBoundary, OpNoWordBoundary, OpNoMatch: all zero-width or
+			// unmatchable; nothing to emit.
+		}
+	}
+	return nil, false
+}
+
+// Generates strings which are matched with re. atStart and atEnd say
+// whether the position immediately before re and immediately after re must
+// be the absolute start/end of the whole output, respectively; ok is false
+// if re cannot be satisfied under those constraints (e.g. a "^" that isn't
+// actually at the start of the output). Callers that can't backtrack past
+// this point (ultimately Generate, GenerateShrinkable and Shrinker.replay)
+// fall back to the empty string on failure.
+func (x *Xeger) generateFromRegexp(re *syntax.Regexp, atStart, atEnd bool) (string, bool) {
 	switch re.Op {
 	case syntax.OpLiteral: // matches Runes sequence
-		return string(re.Rune)
+		return string(re.Rune), true
 
 	case syntax.OpCharClass: // matches Runes interpreted as range pair list
+		if r, ok := x.tryInterestingRune(func(r rune) bool { return runeInClass(re.Rune, r) }); ok {
+			return string(r), true
+		}
+
+		if len(x.unicodeTables) > 0 {
+			if ranges := x.charClassUnicodeRanges(re); len(ranges) > 0 {
+				return string(pickFromRanges(ranges, x.randInt(weightRanges(ranges)))), true
+			}
+		}
+
 		sum := 0
 		for i := 0; i < len(re.Rune); i += 2 {
 			sum += 1 + int(re.Rune[i+1]-re.Rune[i])
@@ -107,31 +475,43 @@ func (x *Xeger) generateFromRegexp(re *syntax.Regexp) string {
 		for i := 0; i < len(re.Rune); i += 2 {
 			delta := re.Rune[i+1] - re.Rune[i]
 			if index <= delta {
-				return string(rune(re.Rune[i] + index))
+				return string(rune(re.Rune[i] + index)), true
 			}
 			index -= delta + 1
 		}
-		return ""
+		return "", true
 
 	case syntax.OpAnyCharNotNL: // matches any character except newline
+		if r, ok := x.tryInterestingRune(func(r rune) bool { return r != '\n' }); ok {
+			return string(r), true
+		}
+		if len(x.unicodeTables) > 0 {
+			return string(x.randRuneFromTables(x.unicodeTables, '\n')), true
+		}
 		c := printableNotNL[x.randInt(len(printableNotNL))]
-		return string([]byte{c})
+		return string([]byte{c}), true
 
 	case syntax.OpAnyChar: // matches any character
+		if r, ok := x.tryInterestingRune(func(rune) bool { return true }); ok {
+			return string(r), true
+		}
+		if len(x.unicodeTables) > 0 {
+			return string(x.randRuneFromTables(x.unicodeTables, -1)), true
+		}
 		c := printable[x.randInt(len(printable))]
-		return string([]byte{c})
+		return string([]byte{c}), true
 
 	case syntax.OpCapture: // capturing subexpression with index Cap, optional name Name
-		return x.generateFromSubexpression(re, 1)
+		return x.generateFromConcat(re.Sub, atStart, atEnd)
 
 	case syntax.OpStar: // matches Sub[0] zero or more times
-		return x.generateFromSubexpression(re, x.randInt(x.limit+1))
+		return x.generateFromRepeat(re.Sub, x.randInt(x.limit+1), atStart, atEnd)
 
 	case syntax.OpPlus: // matches Sub[0] one or more times
-		return x.generateFromSubexpression(re, x.randInt(x.limit)+1)
+		return x.generateFromRepeat(re.Sub, x.randInt(x.limit)+1, atStart, atEnd)
 
 	case syntax.OpQuest: // matches Sub[0] zero or one times
-		return x.generateFromSubexpression(re, x.randInt(2))
+		return x.generateFromRepeat(re.Sub, x.randInt(2), atStart, atEnd)
 
 	case syntax.OpRepeat: // matches Sub[0] at least Min times, at most Max (Max == -1 is no limit)
 		max := re.Max
@@ -139,47 +519,625 @@ func (x *Xeger) generateFromRegexp(re *syntax.Regexp) string {
 			max = x.limit
 		}
 		count := x.randInt(max-re.Min+1) + re.Min
-		return x.generateFromSubexpression(re, count)
+		return x.generateFromRepeat(re.Sub, count, atStart, atEnd)
 
 	case syntax.OpConcat: // matches concatenation of Subs
-		return x.generateFromSubexpression(re, 1)
+		return x.generateFromConcat(re.Sub, atStart, atEnd)
 
 	case syntax.OpAlternate: // matches alternation of Subs
-		i := x.randInt(len(re.Sub))
-		return x.generateFromRegexp(re.Sub[i])
+		// Try branches starting from a random one, wrapping around, so a
+		// branch that can't be satisfied under atStart/atEnd (e.g. one
+		// beginning with "^" when atStart is false) doesn't get chosen.
+		n := len(re.Sub)
+		first := x.randInt(n)
+		for k := 0; k < n; k++ {
+			i := (first + k) % n
+			if s, ok := x.generateFromRegexp(re.Sub[i], atStart, atEnd); ok {
+				return s, true
+			}
+		}
+		return "", false
 
-		/*
-			// The other cases return empty string.
-			case syntax.OpNoMatch: // matches no strings
-			case syntax.OpEmptyMatch: // matches empty string
-			case syntax.OpBeginLine: // matches empty string at beginning of line
-			case syntax.OpEndLine: // matches empty string at end of line
-			case syntax.OpBeginText: // matches empty string at beginning of text
-			case syntax.OpEndText: // matches empty string at end of text
-			case syntax.OpWordBoundary: // matches word boundary `\b`
-			case syntax.OpNoWordBoundary: // matches word non-boundary `\B`
-		*/
+	case syntax.OpEmptyMatch, // matches empty string
+		syntax.OpWordBoundary,   // matches word boundary `\b`
+		syntax.OpNoWordBoundary: // matches word non-boundary `\B`
+		// Zero-width; the surrounding concatenation (generateFromConcat) is
+		// responsible for ensuring OpWordBoundary/OpNoWordBoundary are
+		// actually honored by the characters generated on either side.
+		return "", true
+
+	case syntax.OpBeginLine, syntax.OpBeginText: // matches empty string at beginning of line/text
+		// Conservatively require the absolute start of the whole output;
+		// without tracking newline positions this is a safe, if sometimes
+		// stricter-than-necessary, stand-in for "beginning of line".
+		return "", atStart
+
+	case syntax.OpEndLine, syntax.OpEndText: // matches empty string at end of line/text
+		return "", atEnd
+
+		// case syntax.OpNoMatch: // matches no strings; nothing can satisfy it
 	}
 
-	return ""
+	return "", false
 }
 
-// Generates strings from all sub-expressions.
-// If count > 1, repeat to generate.
-func (x *Xeger) generateFromSubexpression(re *syntax.Regexp, count int) string {
-	b := make([]byte, 0, len(re.Sub)*count)
+// generateFromRepeat generates count back-to-back passes over subs (the
+// body of an OpStar/OpPlus/OpQuest/OpRepeat), giving only the first pass
+// atStart and only the last pass atEnd, since only the boundaries of the
+// very first/last repetition can coincide with the boundaries of the whole
+// repeated group.
+func (x *Xeger) generateFromRepeat(subs []*syntax.Regexp, count int, atStart, atEnd bool) (string, bool) {
+	if count <= 0 {
+		return "", true
+	}
+
+	var b []byte
 	for i := 0; i < count; i++ {
-		for _, sub := range re.Sub {
-			b = append(b, x.generateFromRegexp(sub)...)
+		s, ok := x.generateFromConcat(subs, atStart && i == 0, atEnd && i == count-1)
+		if !ok {
+			return "", false
+		}
+		b = append(b, s...)
+	}
+	return string(b), true
+}
+
+// generateFromConcat generates strings for each of subs, a sequence matched
+// in order (as in an OpConcat, a captured group, or one pass over a
+// repeated OpStar/OpPlus/OpQuest/OpRepeat sub), then reconciles any
+// OpWordBoundary/OpNoWordBoundary among them so the adjoining generated
+// characters actually form the required word/non-word transition. atStart
+// and atEnd say whether the position before subs[0] and after subs[len-1]
+// are the absolute start/end of the whole output; ok is false if any sub
+// can't be satisfied given its derived position.
+func (x *Xeger) generateFromConcat(subs []*syntax.Regexp, atStart, atEnd bool) (string, bool) {
+	parts := make([]string, len(subs))
+	for i, sub := range subs {
+		s, ok := x.generateFromRegexp(sub, atStart && i == 0, atEnd && i == len(subs)-1)
+		if !ok {
+			return "", false
+		}
+		parts[i] = s
+	}
+	for i, sub := range subs {
+		switch sub.Op {
+		case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+			x.reconcileWordBoundary(subs, parts, i, sub.Op == syntax.OpWordBoundary)
+		}
+	}
+
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return string(b), true
+}
+
+// reconcileWordBoundary tries to make parts satisfy the word boundary (or
+// non-boundary) assertion at subs[i] by regenerating one of its immediate
+// neighbors with the wordness it needs at the edge adjoining subs[i] — via
+// steerWordness, which knows how to reach into a single-rune op directly, or
+// recurse through a group (OpCapture, OpConcat, OpStar, OpPlus, OpQuest,
+// OpRepeat, OpAlternate) to steer whichever of its own leaves ends up at
+// that edge. If neither neighbor can be steered (e.g. both are fixed
+// literals all the way down), the assertion can still be forced by growing
+// the output when subs[i] sits at the very edge of the whole concatenation:
+// there's no adjacency requirement to break by inserting an extra word/
+// non-word rune beyond the current start/end. An assertion stuck between
+// two unsteerable neighbors with no edge to extend is left as best effort,
+// since inserting anything between them would break the literal match
+// itself.
+func (x *Xeger) reconcileWordBoundary(subs []*syntax.Regexp, parts []string, i int, wantBoundary bool) {
+	// Treat the start/end of the whole concatenation as a non-word position,
+	// matching Go's own word-boundary semantics.
+	leftWord := lastRuneWordness(parts[:i])
+	rightWord := firstRuneWordness(parts[i+1:])
+
+	isBoundary := leftWord != rightWord
+	if isBoundary == wantBoundary {
+		return
+	}
+
+	if i+1 < len(subs) {
+		wantRightWord := leftWord != wantBoundary
+		if s, ok := x.steerWordness(subs[i+1], wantRightWord, true); ok {
+			parts[i+1] = s
+			return
+		}
+	}
+	if i > 0 {
+		wantLeftWord := rightWord != wantBoundary
+		if s, ok := x.steerWordness(subs[i-1], wantLeftWord, false); ok {
+			parts[i-1] = s
+			return
+		}
+	}
+
+	if i == 0 {
+		parts[i] = x.generateFillerRune(rightWord != wantBoundary)
+		return
+	}
+	if i == len(subs)-1 {
+		parts[i] = x.generateFillerRune(leftWord != wantBoundary)
+		return
+	}
+}
+
+// lastRuneWordness returns the wordness of the last rune among the
+// concatenation of parts, skipping empty parts, or false if parts (and
+// therefore the position before them) is entirely empty, i.e. the start of
+// the whole concatenation.
+func lastRuneWordness(parts []string) bool {
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "" {
+			continue
+		}
+		r := []rune(parts[i])
+		return isWordRune(r[len(r)-1])
+	}
+	return false
+}
+
+// firstRuneWordness is lastRuneWordness's mirror image for the first rune
+// among the concatenation of parts.
+func firstRuneWordness(parts []string) bool {
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		r := []rune(p)
+		return isWordRune(r[0])
+	}
+	return false
+}
+
+// isWordRune reports whether r is a "word" character per the regexp
+// package's own word-boundary semantics: an ASCII letter, digit, or
+// underscore.
+func isWordRune(r rune) bool {
+	return syntax.IsWordChar(r)
+}
+
+// generateRuneWithWordness tries to regenerate sub, a single-rune op, so
+// that its result has the given wordness. It reports ok=false if sub isn't
+// a single-rune op it knows how to steer, or if no rune satisfying both sub
+// and the wordness constraint exists.
+func (x *Xeger) generateRuneWithWordness(sub *syntax.Regexp, wantWord bool) (string, bool) {
+	switch sub.Op {
+	case syntax.OpCharClass:
+		classRanges := make([][2]rune, 0, len(sub.Rune)/2)
+		for i := 0; i < len(sub.Rune); i += 2 {
+			classRanges = append(classRanges, [2]rune{sub.Rune[i], sub.Rune[i+1]})
+		}
+		ranges := classRanges
+		if wantWord {
+			ranges = intersectRanges(classRanges, wordRuneRanges)
+		} else {
+			ranges = subtractRanges(classRanges, wordRuneRanges)
+		}
+		if len(ranges) == 0 {
+			return "", false
+		}
+		return string(pickFromRanges(ranges, x.randInt(weightRanges(ranges)))), true
+
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		chars := printableNotNLNonWordChars
+		if wantWord {
+			chars = printableWordChars
+		}
+		if len(chars) == 0 {
+			return "", false
+		}
+		c := chars[x.randInt(len(chars))]
+		return string([]byte{c}), true
+	}
+	return "", false
+}
+
+// steerWordness tries to regenerate re so that the rune at its leading edge
+// (the one that would end up adjacent to a word-boundary assertion to re's
+// left, if leading is true) or its trailing edge (adjacent to an assertion
+// to re's right, if leading is false) has the given wordness. Unlike
+// generateRuneWithWordness, which only handles re being a single-rune op
+// directly, steerWordness recurses through groups (OpCapture, OpConcat,
+// OpStar, OpPlus, OpQuest, OpRepeat, OpAlternate) to find a steerable leaf at
+// that edge, regenerating the rest of re normally. It reports ok=false if no
+// such leaf exists (e.g. re is, or reduces to, a fixed literal on that
+// side) — anchors nested inside re aren't re-validated against their
+// original position, since steering only ever happens away from the very
+// edge of the whole output.
+func (x *Xeger) steerWordness(re *syntax.Regexp, wantWord, leading bool) (string, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		// A literal's runes are fixed, but it still "steers" successfully
+		// if the rune already at the relevant edge happens to have the
+		// wanted wordness (e.g. an OpAlternate branch consisting of a
+		// literal that starts or ends with a space or digit).
+		if len(re.Rune) == 0 {
+			return "", false
+		}
+		r := re.Rune[0]
+		if !leading {
+			r = re.Rune[len(re.Rune)-1]
+		}
+		if isWordRune(r) != wantWord {
+			return "", false
+		}
+		return string(re.Rune), true
+
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return x.generateRuneWithWordness(re, wantWord)
+
+	case syntax.OpCapture, syntax.OpConcat:
+		return x.steerWordnessInConcat(re.Sub, wantWord, leading)
+
+	case syntax.OpStar:
+		return x.steerWordnessInRepeat(re.Sub, x.randInt(x.limit)+1, wantWord, leading)
+
+	case syntax.OpPlus:
+		return x.steerWordnessInRepeat(re.Sub, x.randInt(x.limit)+1, wantWord, leading)
+
+	case syntax.OpQuest:
+		return x.steerWordnessInRepeat(re.Sub, 1, wantWord, leading)
+
+	case syntax.OpRepeat:
+		max := re.Max
+		if max == -1 {
+			max = x.limit
+		}
+		min := re.Min
+		if min < 1 {
+			min = 1
+		}
+		count := x.randInt(max-min+1) + min
+		return x.steerWordnessInRepeat(re.Sub, count, wantWord, leading)
+
+	case syntax.OpAlternate:
+		// As in generateFromRegexp's own OpAlternate case, try branches
+		// starting from a random one so a branch whose edge can't be
+		// steered doesn't always lose to the first one tried.
+		n := len(re.Sub)
+		first := x.randInt(n)
+		for k := 0; k < n; k++ {
+			i := (first + k) % n
+			if s, ok := x.steerWordness(re.Sub[i], wantWord, leading); ok {
+				return s, true
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// steerWordnessInConcat steers whichever of subs will land at the relevant
+// edge of their concatenation — subs[0] if leading, subs[len(subs)-1]
+// otherwise — generating the rest of subs normally. It reports ok=false if
+// that edge sub itself can't be steered.
+func (x *Xeger) steerWordnessInConcat(subs []*syntax.Regexp, wantWord, leading bool) (string, bool) {
+	if len(subs) == 0 {
+		return "", false
+	}
+	edge := 0
+	if !leading {
+		edge = len(subs) - 1
+	}
+
+	steered, ok := x.steerWordness(subs[edge], wantWord, leading)
+	if !ok {
+		return "", false
+	}
+
+	parts := make([]string, len(subs))
+	parts[edge] = steered
+	for i, sub := range subs {
+		if i == edge {
+			continue
+		}
+		s, ok := x.generateFromRegexp(sub, false, false)
+		if !ok {
+			return "", false
+		}
+		parts[i] = s
+	}
+
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return string(b), true
+}
+
+// steerWordnessInRepeat is steerWordnessInConcat's analog for a repeated
+// body (OpStar/OpPlus/OpQuest/OpRepeat): of the count back-to-back passes
+// over subs, only the first (if leading) or last (otherwise) carries the
+// steered edge, so count is always forced to at least 1 — a repetition that
+// could also validly occur zero times wouldn't give the assertion anything
+// to steer.
+func (x *Xeger) steerWordnessInRepeat(subs []*syntax.Regexp, count int, wantWord, leading bool) (string, bool) {
+	if count <= 0 {
+		return "", false
+	}
+	edge := 0
+	if !leading {
+		edge = count - 1
+	}
+
+	steered, ok := x.steerWordnessInConcat(subs, wantWord, leading)
+	if !ok {
+		return "", false
+	}
+
+	reps := make([]string, count)
+	reps[edge] = steered
+	for i := 0; i < count; i++ {
+		if i == edge {
+			continue
+		}
+		s, ok := x.generateFromConcat(subs, false, false)
+		if !ok {
+			return "", false
+		}
+		reps[i] = s
+	}
+
+	var b []byte
+	for _, r := range reps {
+		b = append(b, r...)
+	}
+	return string(b), true
+}
+
+// generateFillerRune produces a single rune of the given wordness, for use
+// when reconcileWordBoundary needs to grow the output at the edge of the
+// whole concatenation rather than steer an existing neighbor.
+func (x *Xeger) generateFillerRune(wantWord bool) string {
+	chars := printableNotNLNonWordChars
+	if wantWord {
+		chars = printableWordChars
+	}
+	return string([]byte{chars[x.randInt(len(chars))]})
+}
+
+// wordRuneRanges are the ASCII ranges matched by isWordRune, as used to
+// filter OpCharClass ranges by wordness.
+var wordRuneRanges = [][2]rune{{'0', '9'}, {'A', 'Z'}, {'_', '_'}, {'a', 'z'}}
+
+// printableWordChars and printableNotNLNonWordChars are the word/non-word
+// partitions of printable and printableNotNL, used by generateRuneWithWordness.
+var (
+	printableWordChars         = filterByWordness(printable, true)
+	printableNotNLNonWordChars = filterByWordness(printableNotNL, false)
+)
+
+func filterByWordness(s string, word bool) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if isWordRune(rune(s[i])) == word {
+			b = append(b, s[i])
 		}
 	}
 	return string(b)
 }
 
+// subtractRanges removes the portions of b covered from a, returning what's
+// left of a.
+func subtractRanges(a, b [][2]rune) [][2]rune {
+	result := append([][2]rune(nil), a...)
+	for _, s := range b {
+		var next [][2]rune
+		for _, r := range result {
+			if s[1] < r[0] || s[0] > r[1] {
+				next = append(next, r)
+				continue
+			}
+			if s[0] > r[0] {
+				next = append(next, [2]rune{r[0], s[0] - 1})
+			}
+			if s[1] < r[1] {
+				next = append(next, [2]rune{s[1] + 1, r[1]})
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// runeInClass reports whether r falls within ranges, a Rune range pair list
+// as found on syntax.Regexp.
+func runeInClass(ranges []rune, r rune) bool {
+	for i := 0; i < len(ranges); i += 2 {
+		if r >= ranges[i] && r <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+// randFloat returns a pseudo-random float64 in [0,1).
+func (x *Xeger) randFloat() float64 {
+	const precision = 1 << 24
+	return float64(x.randInt(precision)) / float64(precision)
+}
+
+// tryInterestingRune decides, based on x's configured probability, whether
+// to emit an edge-case rune from x.interestingRunes instead of an ordinary
+// one. It returns a rune accepted by allowed and ok=true only if the
+// probability fires and the pool contains at least one rune allowed accepts.
+func (x *Xeger) tryInterestingRune(allowed func(rune) bool) (rune, bool) {
+	if len(x.interestingRunes) == 0 || x.interestingProb <= 0 {
+		return 0, false
+	}
+	if x.randFloat() >= x.interestingProb {
+		return 0, false
+	}
+
+	candidates := make([]rune, 0, len(x.interestingRunes))
+	for _, r := range x.interestingRunes {
+		if allowed(r) {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	return candidates[x.randInt(len(candidates))], true
+}
+
+// rangeTableSize returns the number of runes covered by t.
+func rangeTableSize(t *unicode.RangeTable) int {
+	size := 0
+	for _, r := range t.R16 {
+		size += int((r.Hi-r.Lo)/r.Stride) + 1
+	}
+	for _, r := range t.R32 {
+		size += int((r.Hi-r.Lo)/r.Stride) + 1
+	}
+	return size
+}
+
+// runeAt returns the index-th rune (0-based) covered by t.
+func runeAt(t *unicode.RangeTable, index int) rune {
+	for _, r := range t.R16 {
+		n := int((r.Hi-r.Lo)/r.Stride) + 1
+		if index < n {
+			return rune(int(r.Lo) + index*int(r.Stride))
+		}
+		index -= n
+	}
+	for _, r := range t.R32 {
+		n := int((r.Hi-r.Lo)/r.Stride) + 1
+		if index < n {
+			return rune(int(r.Lo) + index*int(r.Stride))
+		}
+		index -= n
+	}
+	return unicode.ReplacementChar
+}
+
+// randRuneFromTables draws a rune uniformly from the runes of tables taken
+// one after another, so a rune covered by several tables is drawn once per
+// table that covers it. If
+// exclude is a valid rune (>= 0), the draw is retried a bounded number of
+// times to avoid returning it, falling back to a space.
+func (x *Xeger) randRuneFromTables(tables []*unicode.RangeTable, exclude rune) rune {
+	total := 0
+	for _, t := range tables {
+		total += rangeTableSize(t)
+	}
+	if total == 0 {
+		return unicode.ReplacementChar
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		index := x.randInt(total)
+		for _, t := range tables {
+			size := rangeTableSize(t)
+			if index < size {
+				if r := runeAt(t, index); r != exclude {
+					return r
+				}
+				break
+			}
+			index -= size
+		}
+		if exclude < 0 {
+			break
+		}
+	}
+	return ' '
+}
+
+// charClassUnicodeRanges intersects re's Rune range pair list with x's
+// configured Unicode tables, so generated runes satisfy both the class and
+// the category constraint.
+func (x *Xeger) charClassUnicodeRanges(re *syntax.Regexp) [][2]rune {
+	classRanges := make([][2]rune, 0, len(re.Rune)/2)
+	for i := 0; i < len(re.Rune); i += 2 {
+		classRanges = append(classRanges, [2]rune{re.Rune[i], re.Rune[i+1]})
+	}
+
+	var tableRanges [][2]rune
+	for _, t := range x.unicodeTables {
+		tableRanges = append(tableRanges, expandRangeTable(t)...)
+	}
+
+	return intersectRanges(classRanges, tableRanges)
+}
+
+// expandRangeTable flattens a RangeTable into a list of [lo,hi] stride-1
+// intervals, splitting strided ranges into individual single-rune intervals.
+func expandRangeTable(t *unicode.RangeTable) [][2]rune {
+	var out [][2]rune
+	for _, r := range t.R16 {
+		if r.Stride == 1 {
+			out = append(out, [2]rune{rune(r.Lo), rune(r.Hi)})
+			continue
+		}
+		for c := r.Lo; c <= r.Hi; c += r.Stride {
+			out = append(out, [2]rune{rune(c), rune(c)})
+		}
+	}
+	for _, r := range t.R32 {
+		if r.Stride == 1 {
+			out = append(out, [2]rune{rune(r.Lo), rune(r.Hi)})
+			continue
+		}
+		for c := r.Lo; c <= r.Hi; c += r.Stride {
+			out = append(out, [2]rune{rune(c), rune(c)})
+		}
+	}
+	return out
+}
+
+// intersectRanges returns the intersection of two interval lists.
+func intersectRanges(a, b [][2]rune) [][2]rune {
+	var out [][2]rune
+	for _, ra := range a {
+		for _, rb := range b {
+			lo, hi := ra[0], ra[1]
+			if rb[0] > lo {
+				lo = rb[0]
+			}
+			if rb[1] < hi {
+				hi = rb[1]
+			}
+			if lo <= hi {
+				out = append(out, [2]rune{lo, hi})
+			}
+		}
+	}
+	return out
+}
+
+// weightRanges returns the total number of runes covered by ranges.
+func weightRanges(ranges [][2]rune) int {
+	sum := 0
+	for _, r := range ranges {
+		sum += int(r[1]-r[0]) + 1
+	}
+	return sum
+}
+
+// pickFromRanges returns the index-th rune (0-based) covered by ranges.
+func pickFromRanges(ranges [][2]rune, index int) rune {
+	for _, r := range ranges {
+		delta := int(r[1] - r[0])
+		if index <= delta {
+			return r[0] + rune(index)
+		}
+		index -= delta + 1
+	}
+	return unicode.ReplacementChar
+}
+
 // Returns a non-negative pseudo-random number in [0,n).
 // n must be > 0, but int31n does not check this; the caller must ensure it.
 // randInt is simpler and faster than rand.Intn(n), because xeger just
 // generates strings at random.
 func (x *Xeger) randInt(n int) int {
-	return int(x.source.Int63() % int64(n))
+	v := int(x.source.Int63() % int64(n))
+	if x.recording != nil {
+		*x.recording = append(*x.recording, choice{n: n, value: v})
+	}
+	return v
 }
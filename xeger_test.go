@@ -0,0 +1,290 @@
+package xeger
+
+import (
+	"io"
+	"regexp"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestGenerateMatchesWordBoundaries generates many samples for patterns
+// exercising \b/\B at various positions (including right at the edge of the
+// whole output, where there's no neighboring character to steer) and checks
+// each one actually matches via the standard regexp package.
+func TestGenerateMatchesWordBoundaries(t *testing.T) {
+	patterns := []string{
+		`\bfoo\b`,
+		`\Bfoo\B`,
+		`\bcat\B`,
+		`\Bcat\b`,
+		`foo\B\d+`,
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			re := regexp.MustCompile(pattern)
+			x, err := NewXeger(pattern)
+			if err != nil {
+				t.Fatalf("NewXeger(%q): %v", pattern, err)
+			}
+
+			for i := 0; i < 200; i++ {
+				s := x.Generate()
+				if !re.MatchString(s) {
+					t.Fatalf("Generate() = %q, does not match %q", s, pattern)
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateNNonPositiveReturnsEmpty checks that GenerateN doesn't panic
+// on a non-positive maxBytes (make([]byte, 0, maxBytes) would panic for a
+// negative capacity), returning "" instead.
+func TestGenerateNNonPositiveReturnsEmpty(t *testing.T) {
+	x, err := NewXeger(`.*`)
+	if err != nil {
+		t.Fatalf("NewXeger: %v", err)
+	}
+
+	for _, maxBytes := range []int{-1, -1000, 0} {
+		if s := x.GenerateN(maxBytes); s != "" {
+			t.Errorf("GenerateN(%d) = %q, want \"\"", maxBytes, s)
+		}
+	}
+}
+
+// TestGenerateNTruncatesOnRuneBoundary checks that GenerateN's output is
+// always valid UTF-8 and within the requested cap, even for a pattern whose
+// runes are all multi-byte (so most maxBytes values fall mid-rune).
+func TestGenerateNTruncatesOnRuneBoundary(t *testing.T) {
+	x, err := NewXeger(`中{50,100}`)
+	if err != nil {
+		t.Fatalf("NewXeger: %v", err)
+	}
+
+	for maxBytes := 1; maxBytes <= 10; maxBytes++ {
+		s := x.GenerateN(maxBytes)
+		if len(s) > maxBytes {
+			t.Fatalf("GenerateN(%d) = %q, len %d exceeds maxBytes", maxBytes, s, len(s))
+		}
+		if !utf8.ValidString(s) {
+			t.Fatalf("GenerateN(%d) = %q, not valid UTF-8", maxBytes, s)
+		}
+	}
+}
+
+// TestGenerateReaderMatchesPattern checks that reading a GenerateReader to
+// completion, for a pattern with bounded expansion, yields a string that
+// matches the source regexp.
+func TestGenerateReaderMatchesPattern(t *testing.T) {
+	pattern := `[a-z]{5,10}\d{2,4}`
+	re := regexp.MustCompile(pattern)
+	x, err := NewXeger(pattern)
+	if err != nil {
+		t.Fatalf("NewXeger(%q): %v", pattern, err)
+	}
+
+	for i := 0; i < 50; i++ {
+		b, err := io.ReadAll(x.GenerateReader())
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if !re.MatchString(string(b)) {
+			t.Fatalf("GenerateReader() produced %q, does not match %q", b, pattern)
+		}
+	}
+}
+
+// TestGenerateWithUnicodeMatchesAndStaysInTables checks that WithUnicode
+// samples from the configured RangeTables without breaking the match: "."
+// and character classes should still satisfy the source regexp, and every
+// generated rune should actually fall in at least one configured table.
+func TestGenerateWithUnicodeMatchesAndStaysInTables(t *testing.T) {
+	cases := []struct {
+		pattern string
+		tables  []*unicode.RangeTable
+	}{
+		{`.{5,10}`, []*unicode.RangeTable{unicode.Han}},
+		{`[\p{L}]{5,10}`, []*unicode.RangeTable{unicode.Cyrillic}},
+		{`\w{5,10}`, []*unicode.RangeTable{unicode.Greek, unicode.Nd}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.pattern, func(t *testing.T) {
+			re := regexp.MustCompile(c.pattern)
+			x, err := NewXeger(c.pattern, WithUnicode(c.tables...))
+			if err != nil {
+				t.Fatalf("NewXeger(%q): %v", c.pattern, err)
+			}
+
+			for i := 0; i < 200; i++ {
+				s := x.Generate()
+				if !re.MatchString(s) {
+					t.Fatalf("Generate() = %q, does not match %q", s, c.pattern)
+				}
+				for _, r := range s {
+					if !unicode.In(r, c.tables...) {
+						t.Fatalf("Generate() = %q, rune %q not in configured tables", s, r)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateWithInterestingRunesMatchesAndUsesPool checks that, with the
+// probability pinned to 1.0 and a pool that overlaps the pattern's allowed
+// runes, every generated rune both matches the source regexp and is drawn
+// from the configured pool rather than the ordinary printable set.
+func TestGenerateWithInterestingRunesMatchesAndUsesPool(t *testing.T) {
+	pattern := `[\x00-\x1F]{5,10}`
+	pool := []rune{0x0000, 0x000B, 0x001B, 0x007F}
+	re := regexp.MustCompile(pattern)
+	x, err := NewXeger(pattern, WithInterestingRunes(pool, 1.0))
+	if err != nil {
+		t.Fatalf("NewXeger(%q): %v", pattern, err)
+	}
+
+	allowed := map[rune]bool{0x0000: true, 0x000B: true, 0x001B: true}
+	for i := 0; i < 200; i++ {
+		s := x.Generate()
+		if !re.MatchString(s) {
+			t.Fatalf("Generate() = %q, does not match %q", s, pattern)
+		}
+		for _, r := range s {
+			if !allowed[r] {
+				t.Fatalf("Generate() = %q, rune %q not in the interesting-rune pool (0x7F is outside the class and should've been filtered out)", s, r)
+			}
+		}
+	}
+}
+
+// TestGenerateWithDefaultInterestingRunesMatches checks that the default
+// pool (used when WithInterestingRunes is given an empty slice) still
+// produces matching output.
+func TestGenerateWithDefaultInterestingRunesMatches(t *testing.T) {
+	pattern := `.{5,10}`
+	re := regexp.MustCompile(pattern)
+	x, err := NewXeger(pattern, WithInterestingRunes(nil, 0.5))
+	if err != nil {
+		t.Fatalf("NewXeger(%q): %v", pattern, err)
+	}
+
+	for i := 0; i < 200; i++ {
+		s := x.Generate()
+		if !re.MatchString(s) {
+			t.Fatalf("Generate() = %q, does not match %q", s, pattern)
+		}
+	}
+}
+
+// TestGenerateMatchesWordBoundaryNextToGroup is TestGenerateMatchesWordBoundaries'
+// counterpart for a \b/\B neighbor that's a group rather than a bare
+// literal or character class — reconcileWordBoundary has to recurse through
+// the group (OpCapture, OpAlternate, OpPlus, ...) to find a steerable leaf
+// at the relevant edge.
+func TestGenerateMatchesWordBoundaryNextToGroup(t *testing.T) {
+	patterns := []string{
+		`(foo| )\bbaz`,
+		`([a-z ]+)\bbaz`,
+		`foo\b( bar|baz)`,
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			re := regexp.MustCompile(pattern)
+			x, err := NewXeger(pattern)
+			if err != nil {
+				t.Fatalf("NewXeger(%q): %v", pattern, err)
+			}
+
+			for i := 0; i < 200; i++ {
+				s := x.Generate()
+				if !re.MatchString(s) {
+					t.Fatalf("Generate() = %q, does not match %q", s, pattern)
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateBareWordBoundary checks that a pattern which is itself just a
+// bare \b or \B — with no enclosing concatenation for reconcileWordBoundary
+// to run inside of — still gets reconciled via Generate's own wrapping.
+func TestGenerateBareWordBoundary(t *testing.T) {
+	for _, pattern := range []string{`\b`, `\B`} {
+		t.Run(pattern, func(t *testing.T) {
+			re := regexp.MustCompile(pattern)
+			x, err := NewXeger(pattern)
+			if err != nil {
+				t.Fatalf("NewXeger(%q): %v", pattern, err)
+			}
+
+			for i := 0; i < 200; i++ {
+				s := x.Generate()
+				if !re.MatchString(s) {
+					t.Fatalf("Generate() = %q, does not match %q", s, pattern)
+				}
+			}
+		})
+	}
+}
+
+// TestGenerateShrinkableMatchesAndShrinks checks that GenerateShrinkable's
+// output still matches the source regexp (it's generated the same way as
+// Generate), and that replaying the recorded tape through Shrink produces a
+// string no longer than the original that still matches.
+func TestGenerateShrinkableMatchesAndShrinks(t *testing.T) {
+	pattern := `a{5,20}b{5,20}`
+	re := regexp.MustCompile(pattern)
+	x, err := NewXeger(pattern)
+	if err != nil {
+		t.Fatalf("NewXeger(%q): %v", pattern, err)
+	}
+
+	for i := 0; i < 50; i++ {
+		s, shrinker := x.GenerateShrinkable()
+		if !re.MatchString(s) {
+			t.Fatalf("GenerateShrinkable() = %q, does not match %q", s, pattern)
+		}
+
+		shrunk := shrinker.Shrink(func(candidate string) bool { return len(candidate) > 2 })
+		if !re.MatchString(shrunk) {
+			t.Fatalf("Shrink() = %q, does not match %q", shrunk, pattern)
+		}
+		if len(shrunk) > len(s) {
+			t.Fatalf("Shrink() = %q (len %d), longer than original %q (len %d)", shrunk, len(shrunk), s, len(s))
+		}
+	}
+}
+
+// TestGenerateMatchesAnchorsAndClasses is a broader round-trip check over
+// patterns covering anchors, Simplify()-rewritten constructs, and ordinary
+// character classes, to catch regressions in generateFromRegexp generally.
+func TestGenerateMatchesAnchorsAndClasses(t *testing.T) {
+	patterns := []string{
+		`^abc$`,
+		`^[a-z]{3,5}\d*$`,
+		`(foo|bar)+baz`,
+		`[[:alpha:]]{2,4}-[0-9]{2}`,
+	}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			re := regexp.MustCompile(pattern)
+			x, err := NewXeger(pattern)
+			if err != nil {
+				t.Fatalf("NewXeger(%q): %v", pattern, err)
+			}
+
+			for i := 0; i < 200; i++ {
+				s := x.Generate()
+				if !re.MatchString(s) {
+					t.Fatalf("Generate() = %q, does not match %q", s, pattern)
+				}
+			}
+		})
+	}
+}